@@ -19,6 +19,8 @@ package client
 
 import (
 	"context"
+	"fmt"
+	"log"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -26,7 +28,9 @@ import (
 
 	"github.com/apache/incubator-inlong/tubemq-client-twins/tubemq-client-go/errs"
 	"github.com/apache/incubator-inlong/tubemq-client-twins/tubemq-client-go/metadata"
+	"github.com/apache/incubator-inlong/tubemq-client-twins/tubemq-client-go/metrics"
 	"github.com/apache/incubator-inlong/tubemq-client-twins/tubemq-client-go/protocol"
+	"github.com/apache/incubator-inlong/tubemq-client-twins/tubemq-client-go/store"
 	"github.com/apache/incubator-inlong/tubemq-client-twins/tubemq-client-go/util"
 )
 
@@ -39,13 +43,119 @@ type heartbeatManager struct {
 	consumer   *consumer
 	heartbeats map[string]*heartbeatMetadata
 	mu         sync.Mutex
+	stateStore store.ConsumerStateStore
+	scheduler  *heartbeatScheduler
+	metrics    *metrics.Recorder
 }
 
 func newHBManager(consumer *consumer) *heartbeatManager {
-	return &heartbeatManager{
+	h := &heartbeatManager{
 		consumer:   consumer,
 		heartbeats: make(map[string]*heartbeatMetadata),
+		scheduler:  newHeartbeatScheduler(),
+		metrics:    metrics.NewRecorder(consumer.config.Metrics.Enabled),
 	}
+	stateStore, err := store.New(consumer.config.Store)
+	if err != nil {
+		log.Printf("[tubemq] failed to initialize consumer state store: %v", err)
+		return h
+	}
+	h.stateStore = stateStore
+	if state, err := stateStore.Load(context.Background(), consumer.config.Consumer.Group); err == nil {
+		log.Printf("[tubemq] restored consumer state for group %s: lastEventID=%d, %d partition offsets",
+			state.Group, state.LastEventID, len(state.Offsets))
+		consumer.restoreState(state)
+	} else if err != store.ErrNotFound {
+		log.Printf("[tubemq] failed to restore consumer state: %v", err)
+	}
+	return h
+}
+
+// schedulerParams builds the scheduler's tuning knobs from
+// config.HeartbeatConfig, so the adaptive scheduler doesn't need to know
+// the config package's layout.
+func (h *heartbeatManager) schedulerParams() schedulerParams {
+	cfg := h.consumer.config.Heartbeat
+	return schedulerParams{
+		interval:    cfg.Interval,
+		minInterval: cfg.MinInterval,
+		maxInterval: cfg.MaxInterval,
+		afterFail:   cfg.AfterFail,
+		maxBackoff:  cfg.MaxBackoff,
+		jitterRatio: cfg.JitterRatio,
+	}
+}
+
+// persistState snapshots the consumer's current rebalance/offset progress
+// through the configured store.ConsumerStateStore, so a restarted client can
+// resume instead of relying solely on server-side state. A nil stateStore
+// (store construction failed) makes this a no-op.
+func (h *heartbeatManager) persistState(lastEventID int64, subscriptions []string) {
+	if h.stateStore == nil {
+		return
+	}
+	state := &store.State{
+		Group:         h.consumer.config.Consumer.Group,
+		LastEventID:   lastEventID,
+		Subscriptions: subscriptions,
+		Offsets:       h.consumer.rmtDataCache.GetCommittedOffsets(),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), h.consumer.config.Net.ReadTimeout)
+	defer cancel()
+	if err := h.stateStore.Save(ctx, state); err != nil {
+		log.Printf("[tubemq] failed to persist consumer state: %v", err)
+	}
+}
+
+// register2MasterTraced runs consumer.register2Master on its own goroutine
+// wrapped in an RPC span, so reconnect attempts show up in traces alongside
+// the heartbeats that triggered them.
+func (h *heartbeatManager) register2MasterTraced(address string, needRegTempBroker bool) {
+	go func() {
+		_, span := h.metrics.StartRPCSpan(context.Background(), "Register2Master", address, h.consumer.config.Consumer.Group)
+		err := h.consumer.register2Master(needRegTempBroker)
+		errCode := int32(0)
+		if err != nil {
+			errCode = -1
+		}
+		h.metrics.EndRPCSpan(span, errCode, err)
+	}()
+}
+
+// rotateMaster advances to the next candidate in config.Masters and points
+// the consumer at it, so a master that is down or has stepped down as
+// leader no longer wedges heartbeating. It always picks the entry after the
+// currently active address's own position in config.Masters, rather than an
+// independently seeded counter, so the first rotation can't land back on the
+// master that just failed. It is a no-op when only one distinct master is
+// configured.
+func (h *heartbeatManager) rotateMaster() string {
+	masters := h.consumer.config.Masters
+	if len(masters) <= 1 {
+		return h.consumer.master.Address
+	}
+	old := h.consumer.master.Address
+	idx := 0
+	for i, addr := range masters {
+		if addr == old {
+			idx = i
+			break
+		}
+	}
+	var next string
+	for i := 1; i <= len(masters); i++ {
+		candidate := masters[(idx+i)%len(masters)]
+		if candidate != old {
+			next = candidate
+			break
+		}
+	}
+	if next == "" {
+		return old
+	}
+	h.consumer.master.Address = next
+	log.Printf("[tubemq] heartbeat to master %s failed, failing over to %s", old, next)
+	return next
 }
 
 func (h *heartbeatManager) registerMaster(address string) {
@@ -54,7 +164,7 @@ func (h *heartbeatManager) registerMaster(address string) {
 	if _, ok := h.heartbeats[address]; !ok {
 		h.heartbeats[address] = &heartbeatMetadata{
 			numConnections: 1,
-			timer:          time.AfterFunc(h.consumer.config.Heartbeat.Interval/2, h.consumerHB2Master),
+			timer:          time.AfterFunc(h.scheduler.nextInterval(address, h.schedulerParams())/2, h.consumerHB2Master),
 		}
 	}
 	hm := h.heartbeats[address]
@@ -68,7 +178,8 @@ func (h *heartbeatManager) registerBroker(broker *metadata.Node) {
 	if _, ok := h.heartbeats[broker.GetAddress()]; !ok {
 		h.heartbeats[broker.GetAddress()] = &heartbeatMetadata{
 			numConnections: 1,
-			timer:          time.AfterFunc(h.consumer.config.Heartbeat.Interval, func() { h.consumerHB2Broker(broker) }),
+			timer: time.AfterFunc(h.scheduler.nextInterval(broker.GetAddress(), h.schedulerParams()),
+				func() { h.consumerHB2Broker(broker) }),
 		}
 	}
 	hm := h.heartbeats[broker.GetAddress()]
@@ -94,38 +205,78 @@ func (h *heartbeatManager) consumerHB2Master() {
 	}
 
 	retry := 0
+	notLeader := false
 	for retry < h.consumer.config.Heartbeat.MaxRetryTimes {
+		address := h.consumer.master.Address
 		ctx, cancel := context.WithTimeout(context.Background(), h.consumer.config.Net.ReadTimeout)
-		rsp, err := h.consumer.client.HeartRequestC2M(ctx, m, h.consumer.subInfo, h.consumer.rmtDataCache)
+		spanCtx, span := h.metrics.StartRPCSpan(ctx, "HeartRequestC2M", address, h.consumer.config.Consumer.Group)
+		start := time.Now()
+		rsp, err := h.consumer.client.HeartRequestC2M(spanCtx, m, h.consumer.subInfo, h.consumer.rmtDataCache)
+		rtt := time.Since(start)
+		h.metrics.ObserveMasterHeartbeat(address, rtt)
 		if err != nil {
+			h.scheduler.recordResult(address, rtt, false)
+			h.metrics.EndRPCSpan(span, -1, err)
 			cancel()
+			h.metrics.IncMasterHeartbeatRetry(address)
+			retry++
+			continue
 		}
+		h.scheduler.recordResult(address, rtt, rsp.GetSuccess())
+		h.metrics.EndRPCSpan(span, rsp.GetErrCode(), nil)
 		if rsp.GetSuccess() {
 			cancel()
 			h.processHBResponseM2C(rsp)
 			break
 		} else if rsp.GetErrCode() == errs.RetErrHBNoNode || strings.Index(rsp.GetErrMsg(), "StandbyException") != -1 {
 			cancel()
+			// masterHBRetry is kept for diagnostics/back-compat; the
+			// scheduler now drives backoff off per-address consecutive
+			// failures instead.
 			h.consumer.masterHBRetry++
-			address := h.consumer.master.Address
-			go h.consumer.register2Master(rsp.GetErrCode() != errs.RetErrHBNoNode)
-			if rsp.GetErrCode() != errs.RetErrHBNoNode {
-				hm := h.heartbeats[address]
-				hm.numConnections--
-				if hm.numConnections == 0 {
-					h.mu.Lock()
-					delete(h.heartbeats, address)
-					h.mu.Unlock()
+			notLeader = rsp.GetErrCode() != errs.RetErrHBNoNode
+			next := h.rotateMaster()
+			h.register2MasterTraced(next, notLeader)
+			if notLeader {
+				h.mu.Lock()
+				if hm, ok := h.heartbeats[address]; ok {
+					hm.numConnections--
+					if hm.numConnections == 0 {
+						delete(h.heartbeats, address)
+						h.scheduler.forget(address)
+					}
 				}
+				h.mu.Unlock()
 			}
+			log.Printf("[tubemq] master %s reported %s, reconnecting to %s", address, rsp.GetErrMsg(), next)
 			return
 		}
 		cancel()
+		h.metrics.IncMasterHeartbeatRetry(address)
+		retry++
+	}
+	if retry >= h.consumer.config.Heartbeat.MaxRetryTimes {
+		h.consumer.masterHBRetry++
+		oldAddress := h.consumer.master.Address
+		address := h.rotateMaster()
+		h.register2MasterTraced(address, false)
+		h.mu.Lock()
+		if hm, ok := h.heartbeats[oldAddress]; ok {
+			hm.numConnections--
+			if hm.numConnections == 0 {
+				delete(h.heartbeats, oldAddress)
+				h.scheduler.forget(oldAddress)
+			}
+		}
+		h.mu.Unlock()
+		log.Printf("[tubemq] heartbeat to master exceeded %d retries, reconnecting to %s",
+			h.consumer.config.Heartbeat.MaxRetryTimes, address)
+		return
 	}
 	h.mu.Lock()
 	defer h.mu.Unlock()
 	hm := h.heartbeats[h.consumer.master.Address]
-	hm.timer.Reset(h.nextHeartbeatInterval())
+	hm.timer.Reset(h.scheduler.nextInterval(h.consumer.master.Address, h.schedulerParams()))
 }
 
 func (h *heartbeatManager) processHBResponseM2C(rsp *protocol.HeartResponseM2C) {
@@ -159,17 +310,15 @@ func (h *heartbeatManager) processHBResponseM2C(rsp *protocol.HeartResponseM2C)
 		}
 		e := metadata.NewEvent(event.GetRebalanceId(), event.GetOpType(), subscribeInfo)
 		h.consumer.rmtDataCache.OfferEvent(e)
+		h.metrics.IncRebalanceEvent(fmt.Sprintf("%d", event.GetOpType()))
+		subscriptions := make([]string, 0, len(subscribeInfo))
+		for _, s := range subscribeInfo {
+			subscriptions = append(subscriptions, fmt.Sprintf("%v", s))
+		}
+		h.persistState(event.GetRebalanceId(), subscriptions)
 	}
 }
 
-func (h *heartbeatManager) nextHeartbeatInterval() time.Duration {
-	interval := h.consumer.config.Heartbeat.Interval
-	if h.consumer.masterHBRetry >= h.consumer.config.Heartbeat.MaxRetryTimes {
-		interval = h.consumer.config.Heartbeat.AfterFail
-	}
-	return interval
-}
-
 func (h *heartbeatManager) consumerHB2Broker(broker *metadata.Node) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
@@ -185,12 +334,22 @@ func (h *heartbeatManager) consumerHB2Broker(broker *metadata.Node) {
 	ctx, cancel := context.WithTimeout(context.Background(), h.consumer.config.Net.ReadTimeout)
 	defer cancel()
 
-	rsp, err := h.consumer.client.HeartbeatRequestC2B(ctx, m, h.consumer.subInfo, h.consumer.rmtDataCache)
+	spanCtx, span := h.metrics.StartRPCSpan(ctx, "HeartbeatRequestC2B", broker.GetAddress(), h.consumer.config.Consumer.Group)
+	start := time.Now()
+	rsp, err := h.consumer.client.HeartbeatRequestC2B(spanCtx, m, h.consumer.subInfo, h.consumer.rmtDataCache)
+	rtt := time.Since(start)
+	h.metrics.ObserveBrokerHeartbeat(broker.GetAddress(), rtt)
 	if err != nil {
+		h.scheduler.recordResult(broker.GetAddress(), rtt, false)
+		h.metrics.EndRPCSpan(span, -1, err)
 		return
 	}
+	h.scheduler.recordResult(broker.GetAddress(), rtt, rsp.GetSuccess())
+	h.metrics.EndRPCSpan(span, rsp.GetErrCode(), nil)
 	if rsp.GetSuccess() {
+		removed := make(map[string]bool)
 		if rsp.GetHasPartFailure() {
+			h.metrics.IncHasPartFailure(broker.GetAddress())
 			partitionKeys := make([]string, 0, len(rsp.GetFailureInfo()))
 			for _, fi := range rsp.GetFailureInfo() {
 				pos := strings.Index(fi, ":")
@@ -202,6 +361,7 @@ func (h *heartbeatManager) consumerHB2Broker(broker *metadata.Node) {
 					continue
 				}
 				partitionKeys = append(partitionKeys, partition.GetPartitionKey())
+				removed[partition.GetPartitionKey()] = true
 			}
 			h.consumer.rmtDataCache.RemovePartition(partitionKeys)
 		} else {
@@ -209,21 +369,49 @@ func (h *heartbeatManager) consumerHB2Broker(broker *metadata.Node) {
 				partitionKeys := make([]string, 0, len(partitions))
 				for _, partition := range partitions {
 					partitionKeys = append(partitionKeys, partition.GetPartitionKey())
+					removed[partition.GetPartitionKey()] = true
 				}
 				h.consumer.rmtDataCache.RemovePartition(partitionKeys)
 			}
 		}
+		h.persistState(h.consumer.rmtDataCache.GetLastRebalanceID(), nil)
+		h.reportConsumptionLag(remainingPartitions(partitions, removed))
 	}
 	h.resetBrokerTimer(broker)
 }
 
+// remainingPartitions filters out any partition whose key is in removed, so
+// callers don't report metrics for partitions that were just revoked by the
+// same heartbeat response.
+func remainingPartitions(partitions []*metadata.Partition, removed map[string]bool) []*metadata.Partition {
+	if len(removed) == 0 {
+		return partitions
+	}
+	kept := make([]*metadata.Partition, 0, len(partitions))
+	for _, partition := range partitions {
+		if !removed[partition.GetPartitionKey()] {
+			kept = append(kept, partition)
+		}
+	}
+	return kept
+}
+
+// reportConsumptionLag publishes the current unconsumed-message estimate for
+// each of partitions, so metrics.consumptionLag reflects the same broker
+// heartbeat that just refreshed these partitions' offsets.
+func (h *heartbeatManager) reportConsumptionLag(partitions []*metadata.Partition) {
+	for _, partition := range partitions {
+		h.metrics.SetConsumptionLag(partition.GetPartitionKey(), h.consumer.rmtDataCache.GetPartitionLag(partition))
+	}
+}
+
 func (h *heartbeatManager) resetBrokerTimer(broker *metadata.Node) {
-	interval := h.consumer.config.Heartbeat.Interval
 	partitions := h.consumer.rmtDataCache.GetPartitionByBroker(broker)
 	if len(partitions) == 0 {
 		delete(h.heartbeats, broker.GetAddress())
+		h.scheduler.forget(broker.GetAddress())
 	} else {
 		hm := h.heartbeats[broker.GetAddress()]
-		hm.timer.Reset(interval)
+		hm.timer.Reset(h.scheduler.nextInterval(broker.GetAddress(), h.schedulerParams()))
 	}
 }
@@ -0,0 +1,169 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ * <p>
+ * http://www.apache.org/licenses/LICENSE-2.0
+ * <p>
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// schedulerParams mirrors the fields config.HeartbeatConfig exposes for the
+// adaptive scheduler (Interval, MinInterval, MaxInterval, AfterFail,
+// MaxBackoff, JitterRatio), kept separate so this file has no compile-time
+// dependency on the config package's layout.
+type schedulerParams struct {
+	interval    time.Duration
+	minInterval time.Duration
+	maxInterval time.Duration
+	afterFail   time.Duration
+	maxBackoff  time.Duration
+	jitterRatio float64
+}
+
+// rttAlpha and successRateAlpha control how fast the EWMAs in
+// heartbeatStats forget old samples; lower is slower-moving.
+const (
+	rttAlpha         = 0.2
+	successRateAlpha = 0.2
+)
+
+// heartbeatStats tracks the recent behavior of heartbeats to one address so
+// the scheduler can adapt the next interval instead of hammering every
+// address on a fixed period.
+type heartbeatStats struct {
+	rttEWMA             time.Duration
+	successRate         float64
+	consecutiveFailures int
+}
+
+// heartbeatScheduler computes the next heartbeat interval for an address
+// from its recent success rate and consecutive failures, trading off
+// promptness against load on the master/broker. The RTT EWMA it records is
+// not used in that computation. It is safe for concurrent use.
+type heartbeatScheduler struct {
+	mu    sync.Mutex
+	stats map[string]*heartbeatStats
+}
+
+// neutralSuccessRate is the starting point for an address with no recorded
+// heartbeats yet: successRateFactor(neutralSuccessRate) == 1, so the first
+// interval used is the plain configured one rather than already biased
+// towards the "healthy" or "failing" end.
+const neutralSuccessRate = 0.5
+
+// maxBackoffExponent bounds the exponent used to compute exponential
+// backoff so 2^exponent can never overflow a time.Duration, regardless of
+// how long an address has been failing.
+const maxBackoffExponent = 32
+
+func newHeartbeatScheduler() *heartbeatScheduler {
+	return &heartbeatScheduler{stats: make(map[string]*heartbeatStats)}
+}
+
+// forget drops any stats recorded for address, e.g. once it has been fully
+// unregistered, so long-lived clients that churn through many broker
+// addresses don't leak one heartbeatStats entry per address forever.
+func (s *heartbeatScheduler) forget(address string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.stats, address)
+}
+
+// recordResult folds one heartbeat outcome for address into its running
+// stats. Call it once per RPC, success or failure.
+func (s *heartbeatScheduler) recordResult(address string, rtt time.Duration, success bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats, ok := s.stats[address]
+	if !ok {
+		stats = &heartbeatStats{rttEWMA: rtt, successRate: neutralSuccessRate}
+		s.stats[address] = stats
+	}
+	stats.rttEWMA = time.Duration(float64(stats.rttEWMA)*(1-rttAlpha) + float64(rtt)*rttAlpha)
+	observed := 0.0
+	if success {
+		observed = 1
+		stats.consecutiveFailures = 0
+	} else {
+		stats.consecutiveFailures++
+	}
+	stats.successRate = stats.successRate*(1-successRateAlpha) + observed*successRateAlpha
+}
+
+// nextInterval returns the next heartbeat interval for address given p: a
+// success-rate-scaled interval clamped to [p.minInterval, p.maxInterval]
+// with jitter, or an exponential backoff off p.afterFail capped at
+// p.maxBackoff while address has consecutive failures.
+func (s *heartbeatScheduler) nextInterval(address string, p schedulerParams) time.Duration {
+	s.mu.Lock()
+	stats, ok := s.stats[address]
+	var consecutiveFailures int
+	var successRate float64
+	if ok {
+		consecutiveFailures = stats.consecutiveFailures
+		successRate = stats.successRate
+	} else {
+		successRate = neutralSuccessRate
+	}
+	s.mu.Unlock()
+
+	if consecutiveFailures > 0 {
+		exponent := consecutiveFailures - 1
+		if exponent > maxBackoffExponent {
+			exponent = maxBackoffExponent
+		}
+		backoff := scaleDuration(p.afterFail, math.Pow(2, float64(exponent)))
+		if p.maxBackoff > 0 && (backoff > p.maxBackoff || backoff <= 0) {
+			backoff = p.maxBackoff
+		}
+		return jitter(backoff, p.jitterRatio)
+	}
+
+	interval := scaleDuration(p.interval, successRateFactor(successRate))
+	if p.minInterval > 0 && interval < p.minInterval {
+		interval = p.minInterval
+	}
+	if p.maxInterval > 0 && interval > p.maxInterval {
+		interval = p.maxInterval
+	}
+	return jitter(interval, p.jitterRatio)
+}
+
+// successRateFactor maps a success rate in [0, 1] to a multiplier in
+// [0.5, 1.5]: a consumer that's been heartbeating cleanly backs off towards
+// a longer interval to reduce load, one seeing failures tightens towards a
+// shorter interval to notice recovery sooner.
+func successRateFactor(successRate float64) float64 {
+	return 0.5 + successRate
+}
+
+func scaleDuration(d time.Duration, factor float64) time.Duration {
+	return time.Duration(float64(d) * factor)
+}
+
+// jitter perturbs d by up to +/-ratio (e.g. 0.2 for +/-20%) to avoid many
+// consumers heartbeating in lockstep.
+func jitter(d time.Duration, ratio float64) time.Duration {
+	if ratio <= 0 {
+		return d
+	}
+	delta := (rand.Float64()*2 - 1) * ratio
+	return time.Duration(float64(d) * (1 + delta))
+}
@@ -0,0 +1,136 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ * <p>
+ * http://www.apache.org/licenses/LICENSE-2.0
+ * <p>
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+func testParams() schedulerParams {
+	return schedulerParams{
+		interval:    10 * time.Second,
+		minInterval: 2 * time.Second,
+		maxInterval: 20 * time.Second,
+		afterFail:   time.Second,
+		maxBackoff:  time.Minute,
+		jitterRatio: 0,
+	}
+}
+
+func TestNextIntervalNoHistoryUsesConfiguredInterval(t *testing.T) {
+	s := newHeartbeatScheduler()
+	got := s.nextInterval("addr1", testParams())
+	if got != testParams().interval {
+		t.Fatalf("expected unbiased first interval %v, got %v", testParams().interval, got)
+	}
+}
+
+func TestNextIntervalClampsToMinAndMax(t *testing.T) {
+	s := newHeartbeatScheduler()
+	p := testParams()
+	p.interval = 100 * time.Millisecond
+	if got := s.nextInterval("addr1", p); got != p.minInterval {
+		t.Fatalf("expected interval clamped to minInterval %v, got %v", p.minInterval, got)
+	}
+
+	s2 := newHeartbeatScheduler()
+	p2 := testParams()
+	p2.interval = time.Hour
+	if got := s2.nextInterval("addr1", p2); got != p2.maxInterval {
+		t.Fatalf("expected interval clamped to maxInterval %v, got %v", p2.maxInterval, got)
+	}
+}
+
+func TestRecordResultSuccessResetsConsecutiveFailures(t *testing.T) {
+	s := newHeartbeatScheduler()
+	s.recordResult("addr1", 10*time.Millisecond, false)
+	s.recordResult("addr1", 10*time.Millisecond, false)
+	s.recordResult("addr1", 10*time.Millisecond, true)
+
+	p := testParams()
+	got := s.nextInterval("addr1", p)
+	if got <= 0 || got > p.maxInterval {
+		t.Fatalf("expected a normal interval after a success, got %v", got)
+	}
+}
+
+func TestNextIntervalBacksOffOnConsecutiveFailures(t *testing.T) {
+	s := newHeartbeatScheduler()
+	p := testParams()
+	s.recordResult("addr1", 10*time.Millisecond, false)
+	first := s.nextInterval("addr1", p)
+	s.recordResult("addr1", 10*time.Millisecond, false)
+	second := s.nextInterval("addr1", p)
+	if second <= first {
+		t.Fatalf("expected backoff to grow with consecutive failures, got first=%v second=%v", first, second)
+	}
+}
+
+func TestNextIntervalBackoffCappedAtMaxBackoff(t *testing.T) {
+	s := newHeartbeatScheduler()
+	p := testParams()
+	for i := 0; i < maxBackoffExponent+10; i++ {
+		s.recordResult("addr1", 10*time.Millisecond, false)
+	}
+	got := s.nextInterval("addr1", p)
+	if got != p.maxBackoff {
+		t.Fatalf("expected backoff capped at maxBackoff %v, got %v", p.maxBackoff, got)
+	}
+}
+
+func TestForgetDropsStats(t *testing.T) {
+	s := newHeartbeatScheduler()
+	s.recordResult("addr1", 10*time.Millisecond, false)
+	s.forget("addr1")
+
+	p := testParams()
+	got := s.nextInterval("addr1", p)
+	if got != p.interval {
+		t.Fatalf("expected forgotten address to behave like a fresh one, got %v want %v", got, p.interval)
+	}
+}
+
+func TestJitterStaysWithinRatio(t *testing.T) {
+	base := 10 * time.Second
+	ratio := 0.2
+	min := time.Duration(float64(base) * (1 - ratio))
+	max := time.Duration(float64(base) * (1 + ratio))
+	for i := 0; i < 100; i++ {
+		got := jitter(base, ratio)
+		if got < min || got > max {
+			t.Fatalf("jitter(%v, %v) = %v, want within [%v, %v]", base, ratio, got, min, max)
+		}
+	}
+}
+
+func TestJitterZeroRatioIsNoop(t *testing.T) {
+	base := 10 * time.Second
+	if got := jitter(base, 0); got != base {
+		t.Fatalf("expected jitter with ratio 0 to return base unchanged, got %v", got)
+	}
+}
+
+func TestSuccessRateFactorRange(t *testing.T) {
+	if got := successRateFactor(0); got != 0.5 {
+		t.Fatalf("successRateFactor(0) = %v, want 0.5", got)
+	}
+	if got := successRateFactor(1); got != 1.5 {
+		t.Fatalf("successRateFactor(1) = %v, want 1.5", got)
+	}
+}
@@ -0,0 +1,294 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ * <p>
+ * http://www.apache.org/licenses/LICENSE-2.0
+ * <p>
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/apache/incubator-inlong/tubemq-client-twins/tubemq-client-go/metadata"
+)
+
+// ConsumeResult is returned by a MessageListener to tell the PushConsumer
+// how to proceed with the batch it was just handed.
+type ConsumeResult int
+
+const (
+	// ConsumeSuccess acks the batch and advances the partition.
+	ConsumeSuccess ConsumeResult = iota
+	// ConsumeRetryLater leaves the batch unacked and redelivers it after a
+	// backoff, without suspending the rest of the partition.
+	ConsumeRetryLater
+	// ConsumeSuspend stops delivery for the partition's worker until the
+	// PushConsumer is restarted; use for errors that won't resolve by
+	// simply retrying (e.g. misconfiguration).
+	ConsumeSuspend
+)
+
+// MessageListener is implemented by applications that want messages pushed
+// to them instead of driving GetMessage/Confirm themselves.
+type MessageListener interface {
+	// ConsumeMessage handles one batch pulled from a single partition. msgs
+	// is never empty.
+	ConsumeMessage(ctx context.Context, msgs []*Message) ConsumeResult
+}
+
+// PushConsumerConfig controls how a PushConsumer schedules work across the
+// partitions assigned to it.
+type PushConsumerConfig struct {
+	// MaxConcurrencyPerTopic caps the number of partitions of a single
+	// topic consumed concurrently. Defaults to 1 if <= 0.
+	MaxConcurrencyPerTopic int
+	// MinRetryBackoff and MaxRetryBackoff bound the backoff applied after
+	// consecutive ConsumeRetryLater results for a partition. Defaults to
+	// 200ms and 30s respectively.
+	MinRetryBackoff time.Duration
+	MaxRetryBackoff time.Duration
+}
+
+func (c *PushConsumerConfig) setDefaults() {
+	if c.MaxConcurrencyPerTopic <= 0 {
+		c.MaxConcurrencyPerTopic = 1
+	}
+	if c.MinRetryBackoff <= 0 {
+		c.MinRetryBackoff = 200 * time.Millisecond
+	}
+	if c.MaxRetryBackoff <= 0 {
+		c.MaxRetryBackoff = 30 * time.Second
+	}
+}
+
+// newTopicConsumer builds a *consumer dedicated to a single partition worker.
+// Each worker calls this exactly once, so that no two workers ever share the
+// *consumer whose GetMessage/Confirm they drive: that sharing is what let
+// MaxConcurrencyPerTopic > 1 misroute one partition's confirm onto another
+// partition's pull.
+type newTopicConsumer func() (*consumer, error)
+
+// PushConsumer drives a pull consumer's partitions in a background worker
+// pool and dispatches each batch to a registered MessageListener, so
+// callers don't have to write their own GetMessage/Confirm loop. It is
+// modeled on RocketMQ's push consumer.
+type PushConsumer struct {
+	consumer *consumer
+	cfg      PushConsumerConfig
+
+	mu        sync.Mutex
+	listeners map[string]MessageListener
+	factories map[string]newTopicConsumer
+	workers   map[string]*partitionWorker
+	topicSems map[string]chan struct{}
+
+	closeOnce sync.Once
+	closeCh   chan struct{}
+}
+
+// NewPushConsumer wraps consumer with a push-style dispatch loop. Partitions
+// are discovered from rebalance events offered to consumer.rmtDataCache, the
+// same feed the pull-based heartbeatManager already consumes. consumer
+// itself is only used for that discovery; it never pulls or confirms a
+// message directly, since every partition worker drives its own consumer
+// instance obtained from the factory passed to RegisterListener.
+func NewPushConsumer(consumer *consumer, cfg PushConsumerConfig) *PushConsumer {
+	cfg.setDefaults()
+	return &PushConsumer{
+		consumer:  consumer,
+		cfg:       cfg,
+		listeners: make(map[string]MessageListener),
+		factories: make(map[string]newTopicConsumer),
+		workers:   make(map[string]*partitionWorker),
+		topicSems: make(map[string]chan struct{}),
+		closeCh:   make(chan struct{}),
+	}
+}
+
+// RegisterListener registers listener for topic. newConsumer must return an
+// independent *consumer each time it's called; PushConsumer calls it once
+// per partition worker it starts for topic, so that concurrent workers for
+// the same topic (up to MaxConcurrencyPerTopic) never share a pull cursor.
+// RegisterListener must be called before Start.
+func (p *PushConsumer) RegisterListener(topic string, newConsumer newTopicConsumer, listener MessageListener) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.listeners[topic] = listener
+	p.factories[topic] = newConsumer
+	if _, ok := p.topicSems[topic]; !ok {
+		p.topicSems[topic] = make(chan struct{}, p.cfg.MaxConcurrencyPerTopic)
+	}
+}
+
+// Start begins dispatching rebalanced partitions to their listeners. It
+// returns immediately; delivery happens on background goroutines until
+// Stop is called.
+func (p *PushConsumer) Start() error {
+	go p.rebalanceLoop()
+	return nil
+}
+
+// Stop halts all partition workers and stops polling for rebalance events.
+func (p *PushConsumer) Stop() {
+	p.closeOnce.Do(func() { close(p.closeCh) })
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, w := range p.workers {
+		w.stop()
+	}
+}
+
+// rebalanceLoop watches rmtDataCache for newly assigned partitions and
+// spins up a worker for each one that doesn't already have one, reusing the
+// same rebalance event feed processHBResponseM2C populates via OfferEvent.
+func (p *PushConsumer) rebalanceLoop() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.closeCh:
+			return
+		case <-ticker.C:
+			p.syncWorkers()
+		}
+	}
+}
+
+func (p *PushConsumer) syncWorkers() {
+	assigned := p.consumer.rmtDataCache.GetAssignedPartitions()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	seen := make(map[string]struct{}, len(assigned))
+	for _, partition := range assigned {
+		key := partition.GetPartitionKey()
+		seen[key] = struct{}{}
+		if _, ok := p.workers[key]; ok {
+			continue
+		}
+		listener, ok := p.listeners[partition.GetTopic()]
+		if !ok {
+			continue
+		}
+		newConsumer := p.factories[partition.GetTopic()]
+		partitionConsumer, err := newConsumer()
+		if err != nil {
+			log.Printf("[tubemq] push consumer failed to build a consumer for partition %s: %v", key, err)
+			continue
+		}
+		sem := p.topicSems[partition.GetTopic()]
+		w := newPartitionWorker(partitionConsumer, partition, listener, sem, p.cfg)
+		p.workers[key] = w
+		w.start()
+	}
+	for key, w := range p.workers {
+		if _, ok := seen[key]; !ok {
+			w.stop()
+			delete(p.workers, key)
+		}
+	}
+}
+
+// partitionWorker pulls and dispatches messages for a single partition. It
+// owns its consumer outright - no other partitionWorker holds a reference to
+// it - so in-order delivery within the partition follows from this being the
+// only goroutine that ever calls GetMessage/Confirm on that consumer, rather
+// than from any assumption about how many workers a topic has running.
+type partitionWorker struct {
+	consumer  *consumer
+	partition *metadata.Partition
+	listener  MessageListener
+	sem       chan struct{}
+	cfg       PushConsumerConfig
+
+	stopOnce sync.Once
+	stopCh   chan struct{}
+}
+
+func newPartitionWorker(consumer *consumer, partition *metadata.Partition, listener MessageListener,
+	sem chan struct{}, cfg PushConsumerConfig) *partitionWorker {
+	return &partitionWorker{
+		consumer:  consumer,
+		partition: partition,
+		listener:  listener,
+		sem:       sem,
+		cfg:       cfg,
+		stopCh:    make(chan struct{}),
+	}
+}
+
+func (w *partitionWorker) start() {
+	go w.run()
+}
+
+func (w *partitionWorker) stop() {
+	w.stopOnce.Do(func() { close(w.stopCh) })
+}
+
+func (w *partitionWorker) run() {
+	backoff := w.cfg.MinRetryBackoff
+	for {
+		select {
+		case <-w.stopCh:
+			return
+		case w.sem <- struct{}{}:
+		}
+		suspend, sleepFor := w.processOnce(&backoff)
+		<-w.sem
+		if suspend {
+			return
+		}
+		if sleepFor > 0 {
+			time.Sleep(sleepFor)
+		}
+	}
+}
+
+// processOnce pulls, dispatches and acks/rolls-back a single batch while
+// holding w.sem for the whole cycle, so the per-topic concurrency limit
+// actually bounds concurrent listener execution rather than just pulls.
+func (w *partitionWorker) processOnce(backoff *time.Duration) (suspend bool, sleepFor time.Duration) {
+	result, err := w.consumer.GetMessage()
+	if err != nil {
+		log.Printf("[tubemq] push consumer failed to pull from partition %s: %v", w.partition.GetPartitionKey(), err)
+		return false, *backoff
+	}
+	if len(result.GetMessageList()) == 0 {
+		return false, 0
+	}
+	switch w.listener.ConsumeMessage(context.Background(), result.GetMessageList()) {
+	case ConsumeSuccess:
+		*backoff = w.cfg.MinRetryBackoff
+		if err := w.consumer.Confirm(result.GetConfirmContext(), true); err != nil {
+			log.Printf("[tubemq] push consumer failed to confirm partition %s: %v", w.partition.GetPartitionKey(), err)
+		}
+		return false, 0
+	case ConsumeRetryLater:
+		if err := w.consumer.Confirm(result.GetConfirmContext(), false); err != nil {
+			log.Printf("[tubemq] push consumer failed to roll back partition %s: %v", w.partition.GetPartitionKey(), err)
+		}
+		wait := *backoff
+		*backoff *= 2
+		if *backoff > w.cfg.MaxRetryBackoff {
+			*backoff = w.cfg.MaxRetryBackoff
+		}
+		return false, wait
+	case ConsumeSuspend:
+		log.Printf("[tubemq] push consumer suspending partition %s on listener request", w.partition.GetPartitionKey())
+		return true, 0
+	}
+	return false, 0
+}
@@ -0,0 +1,98 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ * <p>
+ * http://www.apache.org/licenses/LICENSE-2.0
+ * <p>
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package config defines the configuration consumed by package client to
+// build a consumer: which masters to talk to, how heartbeats are scheduled,
+// and RPC/subscription tuning.
+package config
+
+import (
+	"time"
+
+	"github.com/apache/incubator-inlong/tubemq-client-twins/tubemq-client-go/store"
+)
+
+// Config is the top-level configuration passed to a consumer constructor.
+type Config struct {
+	// Masters is the list of master addresses ("host:port") the consumer
+	// can fail over between on heartbeat errors. A single entry disables
+	// failover.
+	Masters []string
+
+	Net       NetConfig
+	Consumer  ConsumerConfig
+	Heartbeat HeartbeatConfig
+	// Store configures the ConsumerStateStore used to persist committed
+	// offsets and rebalance progress across restarts. The zero value
+	// selects store.BackendMemory.
+	Store store.Config
+	// Metrics controls whether this consumer records Prometheus metrics
+	// and OpenTelemetry traces.
+	Metrics MetricsConfig
+}
+
+// MetricsConfig controls per-consumer metrics and tracing collection.
+type MetricsConfig struct {
+	// Enabled turns on metrics and tracing for the consumer built from
+	// this Config. It only gates this consumer's own collection; it has
+	// no effect on other consumers in the same process.
+	Enabled bool
+}
+
+// NetConfig tunes RPC behavior shared across master and broker calls.
+type NetConfig struct {
+	// ReadTimeout bounds every outbound RPC and the state store save that
+	// follows a successful one.
+	ReadTimeout time.Duration
+}
+
+// ConsumerConfig identifies the consumer to the master and bounds how much
+// unconfirmed work it may carry.
+type ConsumerConfig struct {
+	// Group is the consumer group reported on every heartbeat and
+	// subscribe request.
+	Group string
+	// MaxConfirmWait is how long a partition may sit unconfirmed before
+	// HandleExpiredPartitions reclaims it.
+	MaxConfirmWait time.Duration
+	// MaxSubInfoReportInterval is the number of heartbeats the consumer
+	// may skip reporting its full subscription info before it is forced
+	// to report again.
+	MaxSubInfoReportInterval int
+}
+
+// HeartbeatConfig tunes the master/broker heartbeat schedule.
+type HeartbeatConfig struct {
+	// Interval is the base period between heartbeats; the adaptive
+	// scheduler scales it by 0.5x to 1.5x according to recent success rate.
+	Interval time.Duration
+	// MinInterval and MaxInterval clamp the interval the adaptive
+	// scheduler computes from recent success rate.
+	MinInterval time.Duration
+	MaxInterval time.Duration
+	// AfterFail is the base backoff used while a heartbeat is failing;
+	// the scheduler scales it exponentially by consecutive failures.
+	AfterFail time.Duration
+	// MaxBackoff caps the exponential backoff computed from AfterFail.
+	MaxBackoff time.Duration
+	// JitterRatio is the +/- fraction of jitter applied to every
+	// computed interval, e.g. 0.2 for +/-20%.
+	JitterRatio float64
+	// MaxRetryTimes is how many consecutive master heartbeat failures are
+	// tolerated before rotating to the next configured master.
+	MaxRetryTimes int
+}
@@ -0,0 +1,151 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ * <p>
+ * http://www.apache.org/licenses/LICENSE-2.0
+ * <p>
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package metrics exposes Prometheus collectors and OpenTelemetry tracing
+// hooks for the tubemq client, covering master/broker heartbeat latency and
+// retries, rebalance throughput and per-partition consumption lag.
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Recorder gates collection for a single consumer. The underlying Prometheus
+// collectors and OpenTelemetry tracer below are process-global, as is
+// idiomatic for both libraries, but every collection call goes through a
+// Recorder first, so one consumer built with Metrics.Enabled=false can't
+// reach into another consumer's process and silently turn its metrics and
+// tracing off too, the way a single package-level on/off flag would.
+type Recorder struct {
+	enabled bool
+}
+
+// NewRecorder returns a Recorder that records when enabled is true and is a
+// no-op otherwise. enabled is normally sourced from config.Metrics.Enabled
+// when a consumer starts up.
+func NewRecorder(enabled bool) *Recorder {
+	return &Recorder{enabled: enabled}
+}
+
+var (
+	masterHeartbeatLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "tubemq_client",
+		Subsystem: "master_heartbeat",
+		Name:      "latency_seconds",
+		Help:      "Latency of consumer-to-master heartbeat RPCs.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"address"})
+
+	masterHeartbeatRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "tubemq_client",
+		Subsystem: "master_heartbeat",
+		Name:      "retries_total",
+		Help:      "Number of consumer-to-master heartbeat retries, by address.",
+	}, []string{"address"})
+
+	brokerHeartbeatLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "tubemq_client",
+		Subsystem: "broker_heartbeat",
+		Name:      "latency_seconds",
+		Help:      "Latency of consumer-to-broker heartbeat RPCs.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"address"})
+
+	hasPartFailureTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "tubemq_client",
+		Subsystem: "broker_heartbeat",
+		Name:      "has_part_failure_total",
+		Help:      "Number of broker heartbeat responses reporting a partial partition failure.",
+	}, []string{"address"})
+
+	rebalanceEventsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "tubemq_client",
+		Subsystem: "consumer",
+		Name:      "rebalance_events_total",
+		Help:      "Number of rebalance events received from the master, by operation type.",
+	}, []string{"op_type"})
+
+	consumptionLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "tubemq_client",
+		Subsystem: "consumer",
+		Name:      "partition_lag",
+		Help:      "Estimated number of unconsumed messages per partition.",
+	}, []string{"partition"})
+)
+
+// Handler returns the Prometheus scrape handler so it can be embedded in the
+// user's own HTTP server, e.g. mux.Handle("/metrics", metrics.Handler()).
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// ObserveMasterHeartbeat records the latency of a consumer-to-master
+// heartbeat RPC against address.
+func (r *Recorder) ObserveMasterHeartbeat(address string, elapsed time.Duration) {
+	if r == nil || !r.enabled {
+		return
+	}
+	masterHeartbeatLatency.WithLabelValues(address).Observe(elapsed.Seconds())
+}
+
+// IncMasterHeartbeatRetry counts a single heartbeat retry against address.
+func (r *Recorder) IncMasterHeartbeatRetry(address string) {
+	if r == nil || !r.enabled {
+		return
+	}
+	masterHeartbeatRetries.WithLabelValues(address).Inc()
+}
+
+// ObserveBrokerHeartbeat records the latency of a consumer-to-broker
+// heartbeat RPC against address.
+func (r *Recorder) ObserveBrokerHeartbeat(address string, elapsed time.Duration) {
+	if r == nil || !r.enabled {
+		return
+	}
+	brokerHeartbeatLatency.WithLabelValues(address).Observe(elapsed.Seconds())
+}
+
+// IncHasPartFailure counts a broker heartbeat response that reported a
+// partial partition failure.
+func (r *Recorder) IncHasPartFailure(address string) {
+	if r == nil || !r.enabled {
+		return
+	}
+	hasPartFailureTotal.WithLabelValues(address).Inc()
+}
+
+// IncRebalanceEvent counts a rebalance event of the given operation type
+// received from the master.
+func (r *Recorder) IncRebalanceEvent(opType string) {
+	if r == nil || !r.enabled {
+		return
+	}
+	rebalanceEventsTotal.WithLabelValues(opType).Inc()
+}
+
+// SetConsumptionLag records the current estimated lag for partitionKey.
+func (r *Recorder) SetConsumptionLag(partitionKey string, lag int64) {
+	if r == nil || !r.enabled {
+		return
+	}
+	consumptionLag.WithLabelValues(partitionKey).Set(float64(lag))
+}
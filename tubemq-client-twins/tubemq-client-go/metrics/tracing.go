@@ -0,0 +1,55 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ * <p>
+ * http://www.apache.org/licenses/LICENSE-2.0
+ * <p>
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("tubemq-client-go")
+
+// StartRPCSpan starts a span for a heartbeat or register RPC, tagging it
+// with the target address and consumer group. Call EndRPCSpan with the
+// returned span once the RPC completes.
+func (r *Recorder) StartRPCSpan(ctx context.Context, name, address, group string) (context.Context, trace.Span) {
+	if r == nil || !r.enabled {
+		return ctx, trace.SpanFromContext(ctx)
+	}
+	return tracer.Start(ctx, name, trace.WithAttributes(
+		attribute.String("tubemq.address", address),
+		attribute.String("tubemq.group", group),
+	))
+}
+
+// EndRPCSpan annotates span with the RPC's resulting error code (0 means
+// success) and ends it.
+func (r *Recorder) EndRPCSpan(span trace.Span, errCode int32, err error) {
+	if r == nil || !r.enabled {
+		return
+	}
+	span.SetAttributes(attribute.Int64("tubemq.err_code", int64(errCode)))
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
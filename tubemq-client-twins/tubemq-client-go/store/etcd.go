@@ -0,0 +1,85 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ * <p>
+ * http://www.apache.org/licenses/LICENSE-2.0
+ * <p>
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// etcdStore persists state in an etcd v3 cluster, one key per group. It is
+// the backend to use when several consumer processes need a shared,
+// durable view of rebalance and offset state.
+type etcdStore struct {
+	client *clientv3.Client
+	prefix string
+}
+
+func newEtcdStore(endpoints []string, namespace string) (*etcdStore, error) {
+	if len(endpoints) == 0 {
+		return nil, errors.New("store: etcd backend requires Config.Endpoints")
+	}
+	cli, err := clientv3.New(clientv3.Config{
+		Endpoints:   endpoints,
+		DialTimeout: 5 * time.Second,
+	})
+	if err != nil {
+		return nil, err
+	}
+	prefix := namespace
+	if prefix == "" {
+		prefix = "/tubemq/consumer/"
+	}
+	return &etcdStore{client: cli, prefix: prefix}, nil
+}
+
+func (e *etcdStore) key(group string) string {
+	return e.prefix + group
+}
+
+func (e *etcdStore) Save(ctx context.Context, state *State) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	_, err = e.client.Put(ctx, e.key(state.Group), string(data))
+	return err
+}
+
+func (e *etcdStore) Load(ctx context.Context, group string) (*State, error) {
+	rsp, err := e.client.Get(ctx, e.key(group))
+	if err != nil {
+		return nil, err
+	}
+	if len(rsp.Kvs) == 0 {
+		return nil, ErrNotFound
+	}
+	state := &State{}
+	if err := json.Unmarshal(rsp.Kvs[0].Value, state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+func (e *etcdStore) Close() error {
+	return e.client.Close()
+}
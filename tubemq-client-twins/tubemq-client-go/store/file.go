@@ -0,0 +1,78 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ * <p>
+ * http://www.apache.org/licenses/LICENSE-2.0
+ * <p>
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// fileStore persists one JSON file per group under dir. It is intended for
+// single-process deployments that want state to survive a restart without
+// standing up an external store.
+type fileStore struct {
+	dir string
+}
+
+func newFileStore(dir string) (*fileStore, error) {
+	if dir == "" {
+		return nil, errors.New("store: file backend requires Config.Dir")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &fileStore{dir: dir}, nil
+}
+
+func (f *fileStore) path(group string) string {
+	return filepath.Join(f.dir, group+".json")
+}
+
+func (f *fileStore) Save(ctx context.Context, state *State) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	tmp := f.path(state.Group) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, f.path(state.Group))
+}
+
+func (f *fileStore) Load(ctx context.Context, group string) (*State, error) {
+	data, err := os.ReadFile(f.path(group))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	state := &State{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+func (f *fileStore) Close() error {
+	return nil
+}
@@ -0,0 +1,57 @@
+/**
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ * <p>
+ * http://www.apache.org/licenses/LICENSE-2.0
+ * <p>
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package store
+
+import (
+	"context"
+	"sync"
+)
+
+// memoryStore is the default ConsumerStateStore: it keeps the latest state
+// per group in memory and loses it on process restart.
+type memoryStore struct {
+	mu    sync.Mutex
+	state map[string]*State
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{state: make(map[string]*State)}
+}
+
+func (m *memoryStore) Save(ctx context.Context, state *State) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cp := *state
+	m.state[state.Group] = &cp
+	return nil
+}
+
+func (m *memoryStore) Load(ctx context.Context, group string) (*State, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	state, ok := m.state[group]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	cp := *state
+	return &cp, nil
+}
+
+func (m *memoryStore) Close() error {
+	return nil
+}